@@ -14,10 +14,9 @@
 package cmd
 
 import (
-	"fmt"
-	"os/exec"
-	"strings"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/SprintHive/ship/pkg/helm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -28,53 +27,37 @@ var destroyCmd = &cobra.Command{
 	Short: "Removes components installed by ship",
 	Long:  `This will remove all helm releases with release names that match the release names used by the ship installation`,
 	Run: func(cmd *cobra.Command, args []string) {
+		logFormat, _ := cmd.Flags().GetString("log-format")
+		configureLogging(logFormat)
+
 		var charts []HelmChart
 		viper.UnmarshalKey("charts", &charts)
 
-		getHelmReleases()
-		removeReleases(&charts)
+		if err := removeReleases(&charts); err != nil {
+			log.Fatalf("Failed to remove releases: %v", err)
+		}
 	},
 }
 
-func removeReleases(sourceCharts *[]HelmChart) {
-	currentReleases := getHelmReleases()
+func removeReleases(sourceCharts *[]HelmChart) error {
+	currentReleases, err := helm.GetHelmReleases()
+	if err != nil {
+		return err
+	}
+
 	currentReleasesMap := make(map[string]struct{})
 	for _, currentRelease := range currentReleases {
-		currentReleasesMap[currentRelease] = struct{}{}
+		currentReleasesMap[currentRelease.Name] = struct{}{}
 	}
 
+	releaseNames := make([]string, 0, len(*sourceCharts))
 	for _, sourceChart := range *sourceCharts {
 		if _, found := currentReleasesMap[sourceChart.ReleaseName]; found {
-			fmt.Println(fmt.Sprintf("Removing release: %v", sourceChart.ReleaseName))
-			removeHelmRelease(sourceChart.ReleaseName)
+			releaseNames = append(releaseNames, sourceChart.ReleaseName)
 		}
 	}
-}
-
-func removeHelmRelease(releaseName string) {
-	cmdName := "helm"
-	args := []string{"delete", "--purge", releaseName}
-
-	if output, err := exec.Command(cmdName, args...).CombinedOutput(); err != nil {
-		panic(fmt.Sprintf("Failed to remove helm release '%s': %v", releaseName, string(output)))
-	}
-}
-
-func getHelmReleases() []string {
-	cmdName := "helm"
-
-	args := []string{"list", "-q"}
-
-	output, err := exec.Command(cmdName, args...).CombinedOutput()
-	if err != nil {
-		panic(fmt.Sprintf("Failed to remove charts: %v", string(output)))
-	}
-
-	releases := strings.Split(strings.Trim(string(output), "\" "), "\n")
-	// The last line is always empty, so pop it
-	releases = releases[:len(releases)-1]
 
-	return releases
+	return helm.RemoveReleases(releaseNames)
 }
 
 func init() {
@@ -89,4 +72,5 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// destroyCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	destroyCmd.Flags().String("log-format", "text", "Log output format: \"text\" or \"json\".")
 }
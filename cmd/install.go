@@ -15,10 +15,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
+	log "github.com/sirupsen/logrus"
+
+	"github.com/SprintHive/ship/pkg/chartserver"
 	"github.com/SprintHive/ship/pkg/helm"
-	"github.com/SprintHive/ship/pkg/kubectl"
+	"github.com/SprintHive/ship/pkg/release"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -28,7 +31,7 @@ var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Installs the SHIP components into your Kubernetes cluster",
 	Long: `Install a bundle of SHIP components into your Kubernetes cluster using helm.
-	
+
 	The following components will be installed:
 	* Ingress GW (Kong)
 	* Ingress Controller (Kong Controller)
@@ -42,117 +45,103 @@ var installCmd = &cobra.Command{
 	* CI/CD (Jenkins)
 	* Artifact repository (Nexus)`,
 	Run: func(cmd *cobra.Command, args []string) {
+		logFormat, _ := cmd.Flags().GetString("log-format")
+		configureLogging(logFormat)
+
 		domain, err := cmd.Flags().GetString("domain")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, fmt.Sprintf("Failed to get domain flag"))
-			os.Exit(1)
+			log.Fatal("Failed to get domain flag")
 		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		atomic, _ := cmd.Flags().GetBool("atomic")
+		rollbackOnFailure, _ := cmd.Flags().GetBool("rollback-on-failure")
+		parallelism, _ := cmd.Flags().GetInt("parallelism")
+
 		var components []ShipComponent
 		viper.UnmarshalKey("components", &components)
 
-		helm.InstallChartRepo()
-		installComponents(&components, domain)
-	},
-}
+		if err := helm.InstallChartRepo(); err != nil {
+			log.Fatalf("Failed to install chart repo: %v", err)
+		}
 
-func installComponents(components *[]ShipComponent, domain string) {
-	releasesToSkip := make(map[string]struct{})
-	currentReleases := helm.GetHelmReleases()
-	for _, release := range currentReleases {
-		releasesToSkip[release] = struct{}{}
-	}
+		chartServer, err := chartserver.New("")
+		if err != nil {
+			log.Fatalf("Failed to initialise chart server: %v", err)
+		}
+		if err := chartServer.AddRepo(helm.DefaultRepo); err != nil {
+			log.Fatalf("Failed to register default chart repo: %v", err)
+		}
 
-	errors := []error{}
-
-	for _, component := range *components {
-		if _, found := releasesToSkip[component.Chart.ReleaseName]; found {
-			fmt.Printf("Skipping installation of already installed component: %s\n", component.Chart.ChartPath)
-		} else {
-			for _, preInstallSpec := range component.PreInstallResources {
-				if preInstallSpec.PreconditionReady.Resource != (KubernetesResource{}) {
-					if err := waitForResourceReady(&preInstallSpec.PreconditionReady.Resource, preInstallSpec.PreconditionReady.MinReplicas); err != nil {
-						fmt.Printf("Error encountered: %v\n", err)
-						errors = append(errors, err)
-						continue
-					}
-				}
+		for i := range components {
+			components[i].Chart.Atomic = atomic
+			if err := resolveChartPath(chartServer, &components[i].Chart); err != nil {
+				log.Fatalf("Error encountered: %v", err)
+			}
+		}
 
-				// TODO: Fix hardcoded infra namespace
-				kubectl.Create(preInstallSpec.ManifestPath, "infra")
+		engine := release.NewEngine(domain)
+		engine.RollbackOnFailure = rollbackOnFailure
+		engine.Parallelism = parallelism
 
-				if preInstallSpec.WaitForDone != (KubernetesResource{}) {
-					if err := waitForResourceCompleted(&preInstallSpec.WaitForDone); err != nil {
-						fmt.Printf("Error encountered: %v\n", err)
-						errors = append(errors, err)
-						continue
-					}
-				}
+		if dryRun {
+			if err := engine.DryRun(components); err != nil {
+				log.Fatalf("Dry run failed: %v", err)
+			}
+			return
+		}
 
-				if !preInstallSpec.PersistentAfterWait {
-					kubectl.Delete(preInstallSpec.ManifestPath, "infra")
+		events := make(chan release.Event, len(components)*4+1)
+		engine.Events = events
+
+		renderDone := make(chan struct{})
+		go func() {
+			defer close(renderDone)
+			if logFormat == "json" {
+				for event := range events {
+					log.WithFields(log.Fields{
+						"component": event.Component,
+						"phase":     event.Phase,
+						"duration":  event.Duration,
+					}).Info("progress")
 				}
+				return
 			}
-			helm.InstallChart(&component.Chart, domain)
-
-			for _, postInstallSpec := range component.PostInstallResources {
-				if postInstallSpec.PreconditionReady.Resource != (KubernetesResource{}) {
-					if err := waitForResourceReady(&postInstallSpec.PreconditionReady.Resource, postInstallSpec.PreconditionReady.MinReplicas); err != nil {
-						fmt.Printf("Error encountered: %v\n", err)
-						errors = append(errors, err)
-						continue
-					}
-				}
+			release.RenderProgress(events)
+		}()
 
-				// TODO: Fix hardcoded infra namespace
-				kubectl.Create(postInstallSpec.ManifestPath, "infra")
-
-				if postInstallSpec.WaitForDone != (KubernetesResource{}) {
-					if err := waitForResourceCompleted(&postInstallSpec.WaitForDone); err != nil {
-						fmt.Printf("Error encountered: %v\n", err)
-						errors = append(errors, err)
-						continue
-					}
-				}
+		err = engine.Apply(components)
+		close(events)
+		<-renderDone
 
-				if !postInstallSpec.PersistentAfterWait {
-					kubectl.Delete(postInstallSpec.ManifestPath, "infra")
-				}
-			}
+		if err != nil {
+			log.Fatalf("Installation failed: %v", err)
 		}
-	}
 
-	if len(errors) == 0 {
-		fmt.Println("Installation was successful!")
-	} else {
-		fmt.Println("Installation completed with errors:")
-		for _, componentError := range errors {
-			fmt.Println(componentError)
-		}
-	}
+		log.Info("Installation was successful!")
+	},
 }
 
-func waitForResourceReady(kubeResource *KubernetesResource, minReplicas int) error {
-	if kubeResource.Type == "deployment" {
-		kubectl.WaitDeployReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
-	} else if kubeResource.Type == "daemonset" {
-		kubectl.WaitDaemonSetReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
-	} else if kubeResource.Type == "statefulset" {
-		kubectl.WaitStatefulSetReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
-	} else {
-		return fmt.Errorf("unsupported wait precondition type: %s", kubeResource.Type)
+// resolveChartPath rewrites chart.ChartPath from a "name@version" or
+// "oci://..." reference into a local filesystem path, using chartServer to
+// resolve and cache the chart. Charts already given as a filesystem path
+// are left untouched.
+func resolveChartPath(chartServer *chartserver.Server, chart *helm.Chart) error {
+	if !strings.Contains(chart.ChartPath, "@") && !strings.HasPrefix(chart.ChartPath, "oci://") {
+		return nil
 	}
 
-	return nil
-}
+	chartRef, version := chart.ChartPath, chart.ChartVersion
+	if idx := strings.LastIndex(chartRef, "@"); idx != -1 {
+		chartRef, version = chartRef[:idx], chartRef[idx+1:]
+	}
 
-func waitForResourceCompleted(kubeResource *KubernetesResource) error {
-	if kubeResource.Type == "pod" {
-		kubectl.WaitPodCompleted(kubeResource.Name, kubeResource.Namespace)
-	} else {
-		fmt.Fprintf(os.Stderr, fmt.Sprintf("Unsupported wait type: %s\n", kubeResource.Type))
-		return fmt.Errorf("unsupported wait resource type: %s", kubeResource.Type)
+	localPath, err := chartServer.Resolve(chartRef, version)
+	if err != nil {
+		return fmt.Errorf("failed to resolve chart %q: %w", chart.ChartPath, err)
 	}
 
+	chart.ChartPath = localPath
+
 	return nil
 }
 
@@ -170,4 +159,9 @@ func init() {
 	// installCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	installCmd.Flags().StringP("domain", "d", "", "Sets the base domain that will be used for ingress. *.<base domain> should resolve to your Kubernetes cluster.")
 	installCmd.MarkFlagRequired("domain")
+	installCmd.Flags().Bool("dry-run", false, "Render the charts that would be installed without touching the cluster.")
+	installCmd.Flags().Bool("atomic", false, "Roll back a component's own chart install if it fails partway through.")
+	installCmd.Flags().Bool("rollback-on-failure", true, "Roll back previously applied steps if the installation fails partway through.")
+	installCmd.Flags().Int("parallelism", 1, "Maximum number of independent components (per their \"dependsOn\" config) to install concurrently.")
+	installCmd.Flags().String("log-format", "text", "Log output format: \"text\" or \"json\".")
 }
@@ -0,0 +1,29 @@
+// Copyright © 2017 SprintHive (Pty) Ltd (buzz@sprinthive.com)
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// configureLogging sets the global logrus formatter from a --log-format
+// flag value ("text" or "json"), defaulting to text for anything else.
+func configureLogging(format string) {
+	if format == "json" {
+		log.SetFormatter(&log.JSONFormatter{})
+		return
+	}
+
+	log.SetFormatter(&log.TextFormatter{})
+}
@@ -0,0 +1,125 @@
+// Copyright © 2017 SprintHive (Pty) Ltd (buzz@sprinthive.com)
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/SprintHive/ship/pkg/helm"
+	"github.com/SprintHive/ship/pkg/kubectl"
+	"github.com/SprintHive/ship/pkg/release"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows the status of the SHIP components installed in your Kubernetes cluster",
+	Long:  `Prints each configured component's release revision, status, and last-deployed time, along with a summary of the Kubernetes resources its chart manages.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logFormat, _ := cmd.Flags().GetString("log-format")
+		configureLogging(logFormat)
+
+		var components []ShipComponent
+		viper.UnmarshalKey("components", &components)
+
+		installedReleases, err := helm.GetHelmReleases()
+		if err != nil {
+			log.Fatalf("Failed to list helm releases: %v", err)
+		}
+		installedByName := make(map[string]helm.Release, len(installedReleases))
+		for _, release := range installedReleases {
+			installedByName[release.Name] = release
+		}
+
+		hasManagedManifests := false
+
+		for _, component := range components {
+			releaseName := component.Chart.ReleaseName
+			installedRelease, found := installedByName[releaseName]
+			if !found {
+				fmt.Printf("%s: not installed\n", releaseName)
+				continue
+			}
+
+			rel, err := helm.GetRelease(installedRelease.Name, installedRelease.Namespace)
+			if err != nil {
+				log.WithField("release", releaseName).Warnf("Failed to get release status: %v", err)
+				continue
+			}
+
+			fmt.Printf("%s: revision %d, status %s, last deployed %s\n", releaseName, rel.Version, rel.Info.Status, rel.Info.LastDeployed)
+			for _, resource := range summarizeManifest(rel.Manifest) {
+				fmt.Printf("  - %s\n", resource)
+			}
+
+			if len(release.ManagedManifests(component)) > 0 {
+				hasManagedManifests = true
+			}
+		}
+
+		if hasManagedManifests {
+			// TODO: Fix hardcoded infra namespace
+			resources, err := kubectl.GetByLabel("infra")
+			if err != nil {
+				log.Warnf("Failed to query ship-managed resources: %v", err)
+			} else {
+				fmt.Printf("other resources labelled %q:\n", kubectl.ManagedByLabel)
+				for _, resource := range resources {
+					fmt.Printf("  - %s\n", resource)
+				}
+			}
+		}
+	},
+}
+
+// summarizeManifest returns a "Kind/name" line for every Kubernetes
+// resource in a rendered Helm manifest (which concatenates each
+// resource's YAML separated by "---" documents).
+func summarizeManifest(manifest string) []string {
+	var resources []string
+	var kind, name string
+
+	flush := func() {
+		if kind != "" && name != "" {
+			resources = append(resources, fmt.Sprintf("%s/%s", kind, name))
+		}
+		kind, name = "", ""
+	}
+
+	for _, line := range strings.Split(manifest, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "---":
+			flush()
+		case strings.HasPrefix(trimmed, "kind:") && kind == "":
+			kind = strings.TrimSpace(strings.TrimPrefix(trimmed, "kind:"))
+		case strings.HasPrefix(trimmed, "name:") && name == "":
+			name = strings.TrimSpace(strings.TrimPrefix(trimmed, "name:"))
+		}
+	}
+	flush()
+
+	return resources
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().String("log-format", "text", "Log output format: \"text\" or \"json\".")
+}
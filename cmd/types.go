@@ -0,0 +1,26 @@
+// Copyright © 2017 SprintHive (Pty) Ltd (buzz@sprinthive.com)
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/SprintHive/ship/pkg/release"
+
+// These config types now live in pkg/release, which owns the install
+// orchestration that used to be inlined here. They're aliased back into
+// cmd so the "components"/"charts" viper keys keep unmarshalling into the
+// same shapes they always have.
+type (
+	ShipComponent      = release.Component
+	KubernetesResource = release.KubernetesResource
+	HelmChart          = release.HelmChart
+)
@@ -0,0 +1,87 @@
+// Copyright © 2017 SprintHive (Pty) Ltd (buzz@sprinthive.com)
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/SprintHive/ship/pkg/chartserver"
+	"github.com/SprintHive/ship/pkg/helm"
+	"github.com/SprintHive/ship/pkg/release"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrades the SHIP components installed in your Kubernetes cluster",
+	Long:  `Upgrades each already-installed SHIP component to the chart and version currently configured, using Helm's release history.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logFormat, _ := cmd.Flags().GetString("log-format")
+		configureLogging(logFormat)
+
+		domain, err := cmd.Flags().GetString("domain")
+		if err != nil {
+			log.Fatal("Failed to get domain flag")
+		}
+		reuseValues, _ := cmd.Flags().GetBool("reuse-values")
+		force, _ := cmd.Flags().GetBool("force")
+		versionOverrides, _ := cmd.Flags().GetStringToString("version")
+
+		var components []ShipComponent
+		viper.UnmarshalKey("components", &components)
+
+		chartServer, err := chartserver.New("")
+		if err != nil {
+			log.Fatalf("Failed to initialise chart server: %v", err)
+		}
+		if err := chartServer.AddRepo(helm.DefaultRepo); err != nil {
+			log.Fatalf("Failed to register default chart repo: %v", err)
+		}
+
+		for i := range components {
+			chart := &components[i].Chart
+			if version, found := versionOverrides[chart.ReleaseName]; found {
+				chart.ChartVersion = version
+				if idx := strings.LastIndex(chart.ChartPath, "@"); idx != -1 {
+					chart.ChartPath = chart.ChartPath[:idx+1] + version
+				}
+			}
+			if err := resolveChartPath(chartServer, chart); err != nil {
+				log.Fatalf("Error encountered: %v", err)
+			}
+		}
+
+		engine := release.NewEngine(domain)
+		if err := engine.Upgrade(components, versionOverrides, reuseValues, force); err != nil {
+			log.Fatalf("Upgrade failed: %v", err)
+		}
+
+		log.Info("Upgrade was successful!")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(upgradeCmd)
+
+	upgradeCmd.Flags().StringP("domain", "d", "", "Sets the base domain that will be used for ingress. *.<base domain> should resolve to your Kubernetes cluster.")
+	upgradeCmd.MarkFlagRequired("domain")
+	upgradeCmd.Flags().Bool("reuse-values", true, "Reuse each release's last deployed values, with the configured overrides merged on top.")
+	upgradeCmd.Flags().Bool("force", false, "Force resource updates through a replace strategy.")
+	upgradeCmd.Flags().StringToString("version", nil, "Per-component chart version overrides, e.g. --version kong=1.4.0,grafana=2.0.1.")
+	upgradeCmd.Flags().String("log-format", "text", "Log output format: \"text\" or \"json\".")
+}
@@ -0,0 +1,115 @@
+// Package chartserver resolves Helm chart references against one or more
+// configured chart repositories (classic HTTP repos with an index.yaml, or
+// OCI registries) and caches the resulting chart archives locally, so the
+// rest of ship never has to know where a chart actually lives on disk.
+package chartserver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// ociScheme is the URI scheme used to reference charts stored in an OCI
+// registry, e.g. "oci://registry.example.com/charts/mychart".
+const ociScheme = "oci://"
+
+// Repository describes a single Helm chart repository entry as configured
+// via the "repositories" key in ship's config.
+type Repository struct {
+	Name     string
+	URL      string
+	Username string
+	Password string
+}
+
+// Server resolves chart references (repoName/chartName or an oci:// ref) to
+// a local .tgz path, downloading and caching index files and chart
+// archives as needed.
+type Server struct {
+	cacheDir     string
+	repositories map[string]Repository
+	getters      getter.Providers
+	ociClient    *registry.Client
+}
+
+// New creates a Server, loading repository definitions from the
+// "repositories" viper key (a list of {name, url, username, password}
+// entries) and storing downloaded index files and chart archives under
+// cacheDir. If cacheDir is empty, a "ship" directory under Helm's cache
+// home is used.
+func New(cacheDir string) (*Server, error) {
+	var repos []Repository
+	if err := viper.UnmarshalKey("repositories", &repos); err != nil {
+		return nil, fmt.Errorf("failed to parse repositories config: %w", err)
+	}
+
+	if cacheDir == "" {
+		cacheDir = helmpath.CachePath("ship")
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create chart cache directory %q: %w", cacheDir, err)
+	}
+
+	ociClient, err := registry.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI registry client: %w", err)
+	}
+
+	repoMap := make(map[string]Repository, len(repos))
+	for _, r := range repos {
+		repoMap[r.Name] = r
+	}
+
+	return &Server{
+		cacheDir:     cacheDir,
+		repositories: repoMap,
+		getters:      getter.All(cli.New()),
+		ociClient:    ociClient,
+	}, nil
+}
+
+// AddRepo registers a repository entry for later resolution, verifying it
+// is reachable by downloading its index.yaml.
+func (s *Server) AddRepo(entry repo.Entry) error {
+	if _, err := s.downloadIndex(entry); err != nil {
+		return fmt.Errorf("failed to add chart repository %q: %w", entry.Name, err)
+	}
+
+	s.repositories[entry.Name] = Repository{
+		Name:     entry.Name,
+		URL:      entry.URL,
+		Username: entry.Username,
+		Password: entry.Password,
+	}
+
+	return nil
+}
+
+// Resolve turns a chart reference into a local filesystem path to a chart
+// archive, downloading it into the cache if it isn't already there.
+//
+// chartRef is either:
+//   - "oci://registry/repo/chart" - pulled directly from the OCI registry
+//   - "repoName/chartName"        - resolved against a configured
+//     repository's index.yaml
+//
+// For a repository ref, version is a semver constraint (e.g. "1.2.3" or
+// "^1.2.0"); an empty string resolves to the latest non-prerelease
+// version. For an oci:// ref, version is used verbatim as the tag
+// (constraints are not resolved), and an empty version leaves the tag off
+// the reference.
+func (s *Server) Resolve(chartRef, version string) (string, error) {
+	if strings.HasPrefix(chartRef, ociScheme) {
+		return s.resolveOCI(chartRef, version)
+	}
+
+	return s.resolveRepo(chartRef, version)
+}
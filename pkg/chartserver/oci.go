@@ -0,0 +1,36 @@
+package chartserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// resolveOCI pulls a chart directly from an OCI registry (chartRef of the
+// form "oci://registry/repo/chart") and caches it locally, returning the
+// cached path.
+func (s *Server) resolveOCI(chartRef, version string) (string, error) {
+	ref := strings.TrimPrefix(chartRef, ociScheme)
+	if version != "" {
+		ref = fmt.Sprintf("%s:%s", ref, version)
+	}
+
+	cachedPath := filepath.Join(s.cacheDir, fmt.Sprintf("%s.tgz", strings.NewReplacer("/", "-", ":", "-").Replace(ref)))
+	if _, err := os.Stat(cachedPath); err == nil {
+		return cachedPath, nil
+	}
+
+	pullResult, err := s.ociClient.Pull(fmt.Sprintf("%s%s", ociScheme, ref), registry.PullOptWithChart(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to pull OCI chart %q: %w", chartRef, err)
+	}
+
+	if err := os.WriteFile(cachedPath, pullResult.Chart.Data, 0644); err != nil {
+		return "", fmt.Errorf("failed to cache OCI chart %q at %q: %w", chartRef, cachedPath, err)
+	}
+
+	return cachedPath, nil
+}
@@ -0,0 +1,150 @@
+package chartserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// resolveRepo resolves a "repoName/chartName" reference against a
+// configured repository's index.yaml, downloading the matching chart
+// archive into the cache and returning its local path.
+func (s *Server) resolveRepo(chartRef, version string) (string, error) {
+	repoName, chartName, err := splitChartRef(chartRef)
+	if err != nil {
+		return "", err
+	}
+
+	entry, found := s.repositories[repoName]
+	if !found {
+		return "", fmt.Errorf("no repository named %q is configured", repoName)
+	}
+
+	index, err := s.downloadIndex(repoEntry(entry))
+	if err != nil {
+		return "", err
+	}
+
+	chartVersion, err := findVersion(index, chartName, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s@%s: %w", chartRef, version, err)
+	}
+
+	if len(chartVersion.URLs) == 0 {
+		return "", fmt.Errorf("chart %s@%s has no download URLs in %q's index", chartName, chartVersion.Version, repoName)
+	}
+
+	cachedPath := filepath.Join(s.cacheDir, fmt.Sprintf("%s-%s-%s.tgz", repoName, chartName, chartVersion.Version))
+	if _, err := os.Stat(cachedPath); err == nil {
+		return cachedPath, nil
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(entry.URL, chartVersion.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve chart URL for %s@%s: %w", chartName, chartVersion.Version, err)
+	}
+
+	chartGetter, err := s.getters.ByScheme("https")
+	if err != nil {
+		return "", fmt.Errorf("no getter available to download %q: %w", chartURL, err)
+	}
+
+	data, err := chartGetter.Get(chartURL, getter.WithURL(entry.URL), getter.WithBasicAuth(entry.Username, entry.Password))
+	if err != nil {
+		return "", fmt.Errorf("failed to download chart %q: %w", chartURL, err)
+	}
+
+	if err := os.WriteFile(cachedPath, data.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to cache chart %q at %q: %w", chartURL, cachedPath, err)
+	}
+
+	return cachedPath, nil
+}
+
+// downloadIndex fetches and parses a repository's index.yaml, writing it
+// under cacheDir. The index is downloaded afresh on every call; a copy
+// already in cacheDir is not reused.
+func (s *Server) downloadIndex(entry repo.Entry) (*repo.IndexFile, error) {
+	chartRepo, err := repo.NewChartRepository(&entry, s.getters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chart repository %q: %w", entry.Name, err)
+	}
+	chartRepo.CachePath = s.cacheDir
+
+	indexPath, err := chartRepo.DownloadIndexFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download index for repository %q (%s): %w", entry.Name, entry.URL, err)
+	}
+
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index for repository %q: %w", entry.Name, err)
+	}
+
+	return index, nil
+}
+
+// findVersion picks the chart version from index matching the given
+// semver constraint (empty constraint means "latest stable").
+func findVersion(index *repo.IndexFile, chartName, versionConstraint string) (*repo.ChartVersion, error) {
+	versions, found := index.Entries[chartName]
+	if !found || len(versions) == 0 {
+		return nil, fmt.Errorf("chart %q not found in index", chartName)
+	}
+
+	if versionConstraint == "" {
+		for _, candidate := range versions {
+			v, err := semver.NewVersion(candidate.Version)
+			if err != nil {
+				continue
+			}
+			if v.Prerelease() == "" {
+				return candidate, nil
+			}
+		}
+		return nil, fmt.Errorf("chart %q has no stable version", chartName)
+	}
+
+	constraint, err := semver.NewConstraint(versionConstraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q: %w", versionConstraint, err)
+	}
+
+	for _, candidate := range versions {
+		v, err := semver.NewVersion(candidate.Version)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(v) {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no version of chart %q satisfies %q", chartName, versionConstraint)
+}
+
+// splitChartRef splits a "repoName/chartName" reference into its parts.
+func splitChartRef(chartRef string) (repoName, chartName string, err error) {
+	parts := strings.SplitN(chartRef, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("chart reference %q must be in the form repoName/chartName", chartRef)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// repoEntry builds a repo.Entry from a Repository config for use with the
+// Helm SDK's repository helpers.
+func repoEntry(r Repository) repo.Entry {
+	return repo.Entry{
+		Name:     r.Name,
+		URL:      r.URL,
+		Username: r.Username,
+		Password: r.Password,
+	}
+}
@@ -2,15 +2,22 @@ package helm
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
-)
+	"time"
 
-var (
-	cmdName = "helm"
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/strvals"
 )
 
+var settings = cli.New()
+
 // ValueOverride describes a helm override
 type ValueOverride struct {
 	Override string
@@ -19,85 +26,324 @@ type ValueOverride struct {
 
 // Chart contains the information needed to install a helm chart
 type Chart struct {
-	ChartPath   string
-	Namespace   string
-	ReleaseName string
-	Overrides   []ValueOverride
-	ValuesPath  string
+	ChartPath    string
+	ChartVersion string
+	RepoURL      string
+	Namespace    string
+	ReleaseName  string
+	Overrides    []ValueOverride
+	ValuesPath   string
+	Wait         bool
+	Timeout      time.Duration
+	Atomic       bool
 }
 
-// GetHelmReleases returns the list of helm releases in the kubernetes cluster in the active profile
-func GetHelmReleases() []string {
-	args := []string{"list", "-q"}
+// newActionConfig builds a helm action.Configuration scoped to the given
+// namespace, wired up to whatever kubeconfig/context the environment (or
+// settings.KubeConfig/KubeContext) points at.
+func newActionConfig(namespace string) (*action.Configuration, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secrets", func(format string, v ...interface{}) {}); err != nil {
+		return nil, fmt.Errorf("failed to initialise helm client for namespace %q: %w", namespace, err)
+	}
+
+	return actionConfig, nil
+}
+
+// Release identifies a helm release as returned by a cluster-wide list:
+// its name plus the namespace its release state actually lives in. Helm v3
+// stores release state per-namespace (unlike v2's Tiller-global model), so
+// the namespace has to travel with the name wherever it's looked up again.
+type Release struct {
+	Name      string
+	Namespace string
+}
 
-	output, err := exec.Command(cmdName, args...).CombinedOutput()
+// GetHelmReleases returns the list of helm releases in the kubernetes cluster across all namespaces
+func GetHelmReleases() ([]Release, error) {
+	actionConfig, err := newActionConfig("")
 	if err != nil {
-		panic(fmt.Sprintf("Failed to remove charts: %v", string(output)))
+		return nil, err
 	}
 
-	releases := strings.Split(strings.Trim(string(output), "\" "), "\n")
-	// The last line is always empty, so pop it
-	releases = releases[:len(releases)-1]
+	listAction := action.NewList(actionConfig)
+	listAction.All = true
+	listAction.AllNamespaces = true
 
-	return releases
+	releases, err := listAction.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, Release{Name: r.Name, Namespace: r.Namespace})
+	}
+
+	return result, nil
 }
 
 // RemoveReleases removes the provided releases if they are present
-func RemoveReleases(releases []string) {
-	currentReleases := GetHelmReleases()
-	currentReleasesMap := make(map[string]struct{})
+func RemoveReleases(releaseNames []string) error {
+	currentReleases, err := GetHelmReleases()
+	if err != nil {
+		return err
+	}
+
+	currentReleasesByName := make(map[string]Release, len(currentReleases))
 	for _, currentRelease := range currentReleases {
-		currentReleasesMap[currentRelease] = struct{}{}
+		currentReleasesByName[currentRelease.Name] = currentRelease
 	}
 
-	for _, release := range releases {
-		if _, found := currentReleasesMap[release]; found {
-			fmt.Println(fmt.Sprintf("Removing release: %v", release))
-			removeHelmRelease(release)
+	for _, releaseName := range releaseNames {
+		if currentRelease, found := currentReleasesByName[releaseName]; found {
+			log.WithField("release", releaseName).Info("Removing release")
+			if err := removeHelmRelease(currentRelease.Name, currentRelease.Namespace); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
+}
+
+// DefaultRepo is the chart repository ship installs out of the box. It's
+// registered both with helm directly (InstallChartRepo) and with the
+// chartserver, so "sprinthive-dev-charts/foo@1.2.3" chart refs resolve
+// without the user having to duplicate it under a "repositories" config key.
+var DefaultRepo = repo.Entry{
+	Name: "sprinthive-dev-charts",
+	URL:  "https://s3.eu-west-2.amazonaws.com/sprinthive-dev-charts",
 }
 
 // InstallChartRepo installs a given helm repository into the repository config
-func InstallChartRepo() {
-	args := []string{"repo", "add", "sprinthive-dev-charts", "https://s3.eu-west-2.amazonaws.com/sprinthive-dev-charts"}
+func InstallChartRepo() error {
+	return AddRepo(DefaultRepo)
+}
+
+// AddRepo adds the given repository entry to helm's repository config,
+// creating the config file if this is the first repo added.
+func AddRepo(entry repo.Entry) error {
+	repoFile := settings.RepositoryConfig
+
+	file, err := repo.LoadFile(repoFile)
+	if err != nil {
+		file = repo.NewFile()
+	}
+
+	chartRepo, err := repo.NewChartRepository(&entry, getter.All(settings))
+	if err != nil {
+		return fmt.Errorf("failed to create chart repository %q: %w", entry.Name, err)
+	}
 
-	if output, err := exec.Command(cmdName, args...).CombinedOutput(); err != nil {
-		fmt.Fprintf(os.Stderr, fmt.Sprintf("Failed to install sprinthive charts: %v", string(output)))
-		os.Exit(1)
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		return fmt.Errorf("failed to reach chart repository %q at %q: %w", entry.Name, entry.URL, err)
 	}
 
-	fmt.Println("Successfully installed sprinthive chart repo")
+	file.Update(&entry)
+	if err := file.WriteFile(repoFile, 0644); err != nil {
+		return fmt.Errorf("failed to persist chart repository %q: %w", entry.Name, err)
+	}
+
+	log.WithField("repo", entry.Name).Info("Successfully installed chart repo")
+
+	return nil
 }
 
 // InstallChart will install the provided chart into the currently configured Kubernetes cluster
-func InstallChart(chart *Chart, domain string) {
-	fmt.Printf("Installing chart: %s\n", chart.ChartPath)
-	args := []string{"install", chart.ChartPath, "-n", chart.ReleaseName, "--namespace", chart.Namespace}
-
-	for _, valueOverride := range chart.Overrides {
-		var helmFlag string
-		if valueOverride.Type == "string" {
-			helmFlag = "--set-string"
+func InstallChart(chart *Chart, domain string) error {
+	log.WithField("chart", chart.ChartPath).Info("Installing chart")
+
+	actionConfig, err := newActionConfig(chart.Namespace)
+	if err != nil {
+		return err
+	}
+
+	install := action.NewInstall(actionConfig)
+	install.ReleaseName = chart.ReleaseName
+	install.Namespace = chart.Namespace
+	install.CreateNamespace = true
+	install.Version = chart.ChartVersion
+	install.Wait = chart.Wait
+	install.Atomic = chart.Atomic
+	if chart.Timeout > 0 {
+		install.Timeout = chart.Timeout
+	}
+
+	chartPath, err := install.ChartPathOptions.LocateChart(chart.ChartPath, settings)
+	if err != nil {
+		return fmt.Errorf("failed to locate chart %q: %w", chart.ChartPath, err)
+	}
+
+	loadedChart, err := loader.Load(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chart %q: %w", chartPath, err)
+	}
+
+	values, err := buildValues(chart, domain)
+	if err != nil {
+		return err
+	}
+
+	if _, err := install.Run(loadedChart, values); err != nil {
+		return fmt.Errorf("failed to install chart %q: %w", chart.ChartPath, err)
+	}
+
+	return nil
+}
+
+// buildValues merges the chart's values file (if any) and its ValueOverrides
+// (with ${domain} expanded) into a single chartutil.Values map, the same way
+// `helm install --values file --set ...` would.
+func buildValues(chart *Chart, domain string) (chartutil.Values, error) {
+	values := chartutil.Values{}
+
+	if chart.ValuesPath != "" {
+		fileValues, err := chartutil.ReadValuesFile(chart.ValuesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %q: %w", chart.ValuesPath, err)
+		}
+		values = fileValues
+	}
+
+	for _, override := range chart.Overrides {
+		expanded := strings.Replace(override.Override, "${domain}", domain, -1)
+
+		var err error
+		if override.Type == "string" {
+			err = strvals.ParseIntoString(expanded, values)
 		} else {
-			helmFlag = "--set"
+			err = strvals.ParseInto(expanded, values)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse value override %q: %w", override.Override, err)
 		}
-		args = append(args, helmFlag, strings.Replace(valueOverride.Override, "${domain}", domain, -1))
 	}
 
-	if chart.ValuesPath != "" {
-		args = append(args, "--values", chart.ValuesPath)
+	return values, nil
+}
+
+// RenderChart renders the chart's manifests the same way InstallChart
+// would, without installing it (action.Install.DryRun + ClientOnly), for
+// use by dry-run tooling.
+func RenderChart(chart *Chart, domain string) (string, error) {
+	actionConfig, err := newActionConfig(chart.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(actionConfig)
+	install.ReleaseName = chart.ReleaseName
+	install.Namespace = chart.Namespace
+	install.Version = chart.ChartVersion
+	install.DryRun = true
+	install.ClientOnly = true
+
+	chartPath, err := install.ChartPathOptions.LocateChart(chart.ChartPath, settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate chart %q: %w", chart.ChartPath, err)
+	}
+
+	loadedChart, err := loader.Load(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart %q: %w", chartPath, err)
+	}
+
+	values, err := buildValues(chart, domain)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := install.Run(loadedChart, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render chart %q: %w", chart.ChartPath, err)
+	}
+
+	return rel.Manifest, nil
+}
+
+// UpgradeChart upgrades an existing release to the given chart. reuseValues
+// carries over the previous release's values before applying chart.Overrides
+// on top; force updates resources by replacing them instead of patching
+// them.
+func UpgradeChart(chart *Chart, domain string, reuseValues, force bool) error {
+	log.WithField("release", chart.ReleaseName).Info("Upgrading release")
+
+	actionConfig, err := newActionConfig(chart.Namespace)
+	if err != nil {
+		return err
 	}
 
-	if output, err := exec.Command(cmdName, args...).CombinedOutput(); err != nil {
-		panic(fmt.Sprintf("Failed to install chart: %v", string(output)))
+	upgrade := action.NewUpgrade(actionConfig)
+	upgrade.Namespace = chart.Namespace
+	upgrade.Version = chart.ChartVersion
+	upgrade.Wait = chart.Wait
+	upgrade.Atomic = chart.Atomic
+	upgrade.ReuseValues = reuseValues
+	upgrade.Force = force
+	if chart.Timeout > 0 {
+		upgrade.Timeout = chart.Timeout
 	}
+
+	chartPath, err := upgrade.ChartPathOptions.LocateChart(chart.ChartPath, settings)
+	if err != nil {
+		return fmt.Errorf("failed to locate chart %q: %w", chart.ChartPath, err)
+	}
+
+	loadedChart, err := loader.Load(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chart %q: %w", chartPath, err)
+	}
+
+	values, err := buildValues(chart, domain)
+	if err != nil {
+		return err
+	}
+
+	if _, err := upgrade.Run(chart.ReleaseName, loadedChart, values); err != nil {
+		return fmt.Errorf("failed to upgrade release %q: %w", chart.ReleaseName, err)
+	}
+
+	return nil
+}
+
+// GetRelease returns the current release object (revision, status,
+// manifest, etc.) for the given release name in the given namespace, as
+// recorded in Helm's release history.
+func GetRelease(releaseName, namespace string) (*helmrelease.Release, error) {
+	actionConfig, err := newActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	get := action.NewGet(actionConfig)
+
+	rel, err := get.Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %q: %w", releaseName, err)
+	}
+
+	return rel, nil
+}
+
+// UninstallRelease uninstalls a single release from the given namespace.
+// Unlike RemoveReleases, which looks a release's namespace up from a
+// cluster-wide list first, this is for callers (like a rollback) that
+// already know exactly where the release was installed.
+func UninstallRelease(releaseName, namespace string) error {
+	return removeHelmRelease(releaseName, namespace)
 }
 
-func removeHelmRelease(releaseName string) {
-	args := []string{"delete", "--purge", releaseName}
+func removeHelmRelease(releaseName, namespace string) error {
+	actionConfig, err := newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
 
-	if output, err := exec.Command(cmdName, args...).CombinedOutput(); err != nil {
-		panic(fmt.Sprintf("Failed to remove helm release '%s': %v", releaseName, string(output)))
+	uninstall := action.NewUninstall(actionConfig)
+	if _, err := uninstall.Run(releaseName); err != nil {
+		return fmt.Errorf("failed to remove helm release %q: %w", releaseName, err)
 	}
+
+	return nil
 }
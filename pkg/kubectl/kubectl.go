@@ -0,0 +1,137 @@
+// Package kubectl is the thin boundary between ship and a live cluster
+// for everything outside of Helm's own release management: applying and
+// deleting one-off manifests, waiting on workload readiness, and querying
+// resources ship has applied. It shells out to the kubectl binary rather
+// than a client-go client so it always behaves exactly like running the
+// same command by hand, kubeconfig/context and all.
+package kubectl
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ManagedByLabel is stamped onto every resource Create applies, so it can
+// be found again later (e.g. by `ship status`) without ship having to
+// track what it created anywhere else.
+const ManagedByLabel = "app.kubernetes.io/managed-by=ship"
+
+const (
+	pollInterval = 5 * time.Second
+	waitTimeout  = 5 * time.Minute
+)
+
+// Create applies the manifest at manifestPath into namespace, then labels
+// every resource it just applied with ManagedByLabel.
+func Create(manifestPath, namespace string) error {
+	if err := run("apply", "-f", manifestPath, "-n", namespace); err != nil {
+		return fmt.Errorf("failed to apply manifest %q: %w", manifestPath, err)
+	}
+
+	if err := run("label", "--overwrite", "-f", manifestPath, "-n", namespace, ManagedByLabel); err != nil {
+		return fmt.Errorf("failed to label resources applied from %q: %w", manifestPath, err)
+	}
+
+	return nil
+}
+
+// Delete deletes the resources described by the manifest at manifestPath
+// from namespace.
+func Delete(manifestPath, namespace string) error {
+	if err := run("delete", "-f", manifestPath, "-n", namespace, "--ignore-not-found"); err != nil {
+		return fmt.Errorf("failed to delete manifest %q: %w", manifestPath, err)
+	}
+
+	return nil
+}
+
+// GetByLabel returns "resource/name" (as kubectl names them, e.g.
+// "deployment.apps/grafana") for every resource in namespace carrying
+// ManagedByLabel, queried live from the cluster. Only kinds in kubectl's
+// "all" category (pods, services and workload controllers) are queried;
+// ConfigMaps, Secrets and other kinds are not returned.
+func GetByLabel(namespace string) ([]string, error) {
+	out, err := output("get", "all", "-n", namespace, "-l", ManagedByLabel, "-o", "name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resources labelled %q in namespace %q: %w", ManagedByLabel, namespace, err)
+	}
+
+	var resources []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			resources = append(resources, line)
+		}
+	}
+
+	return resources, nil
+}
+
+// WaitDeployReady blocks until the named deployment has at least
+// minReplicas ready replicas, or waitTimeout elapses.
+func WaitDeployReady(name string, minReplicas int, namespace string) {
+	waitReadyReplicas("deployment", name, minReplicas, namespace)
+}
+
+// WaitDaemonSetReady blocks until the named daemonset has at least
+// minReplicas ready replicas, or waitTimeout elapses.
+func WaitDaemonSetReady(name string, minReplicas int, namespace string) {
+	waitReadyReplicas("daemonset", name, minReplicas, namespace)
+}
+
+// WaitStatefulSetReady blocks until the named statefulset has at least
+// minReplicas ready replicas, or waitTimeout elapses.
+func WaitStatefulSetReady(name string, minReplicas int, namespace string) {
+	waitReadyReplicas("statefulset", name, minReplicas, namespace)
+}
+
+// WaitPodCompleted blocks until the named pod reaches the Succeeded
+// phase, or waitTimeout elapses.
+func WaitPodCompleted(name, namespace string) {
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		phase, err := output("get", "pod", name, "-n", namespace, "-o", "jsonpath={.status.phase}")
+		if err == nil && strings.TrimSpace(phase) == "Succeeded" {
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func waitReadyReplicas(resourceType, name string, minReplicas int, namespace string) {
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		out, err := output("get", resourceType, name, "-n", namespace, "-o", "jsonpath={.status.readyReplicas}")
+		if err == nil {
+			var ready int
+			fmt.Sscanf(strings.TrimSpace(out), "%d", &ready)
+			if ready >= minReplicas {
+				return
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func run(args ...string) error {
+	cmd := exec.Command("kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("kubectl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func output(args ...string) (string, error) {
+	cmd := exec.Command("kubectl", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,128 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+)
+
+// buildDependencyGraph validates the DependsOn edges of components (every
+// reference must name a known component, and the graph must be acyclic)
+// and returns, for each component, the names of the components that
+// depend on it.
+func buildDependencyGraph(components []Component) (dependents map[string][]string, err error) {
+	names := make(map[string]struct{}, len(components))
+	for _, component := range components {
+		names[component.Chart.ReleaseName] = struct{}{}
+	}
+
+	dependents = make(map[string][]string, len(components))
+	for _, component := range components {
+		for _, dep := range component.DependsOn {
+			if _, found := names[dep]; !found {
+				return nil, fmt.Errorf("component %q depends on unknown component %q", component.Chart.ReleaseName, dep)
+			}
+			dependents[dep] = append(dependents[dep], component.Chart.ReleaseName)
+		}
+	}
+
+	if cycle := findCycle(components); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+
+	return dependents, nil
+}
+
+// topoOrder returns components in a valid dependency order (a component
+// always appears after everything in its DependsOn), via Kahn's algorithm.
+func topoOrder(components []Component, dependents map[string][]string) ([]Component, error) {
+	byName := make(map[string]Component, len(components))
+	remaining := make(map[string]int, len(components))
+	for _, component := range components {
+		byName[component.Chart.ReleaseName] = component
+		remaining[component.Chart.ReleaseName] = len(component.DependsOn)
+	}
+
+	var queue []string
+	for name, count := range remaining {
+		if count == 0 {
+			queue = append(queue, name)
+		}
+	}
+
+	order := make([]Component, 0, len(components))
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		order = append(order, byName[name])
+
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(components) {
+		return nil, fmt.Errorf("dependency cycle detected among components")
+	}
+
+	return order, nil
+}
+
+// findCycle returns the component names that make up a dependency cycle,
+// or nil if the graph is acyclic.
+func findCycle(components []Component) []string {
+	dependsOn := make(map[string][]string, len(components))
+	for _, component := range components {
+		dependsOn[component.Chart.ReleaseName] = component.DependsOn
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(components))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+			return []string{name, name}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range dependsOn[name] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		return nil
+	}
+
+	for name := range dependsOn {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
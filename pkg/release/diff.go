@@ -0,0 +1,78 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderDiff returns a unified, line-based diff between an installed
+// release's manifest and the one its chart would render now: lines only
+// in old are prefixed "-", lines only in updated are prefixed "+", and
+// unchanged lines are passed through with a leading space.
+func renderDiff(old, updated string) string {
+	oldLines := strings.Split(old, "\n")
+	updatedLines := strings.Split(updated, "\n")
+	common := longestCommonSubsequence(oldLines, updatedLines)
+
+	var b strings.Builder
+	i, j := 0, 0
+	for _, line := range common {
+		for i < len(oldLines) && oldLines[i] != line {
+			fmt.Fprintf(&b, "-%s\n", oldLines[i])
+			i++
+		}
+		for j < len(updatedLines) && updatedLines[j] != line {
+			fmt.Fprintf(&b, "+%s\n", updatedLines[j])
+			j++
+		}
+		fmt.Fprintf(&b, " %s\n", line)
+		i++
+		j++
+	}
+	for ; i < len(oldLines); i++ {
+		fmt.Fprintf(&b, "-%s\n", oldLines[i])
+	}
+	for ; j < len(updatedLines); j++ {
+		fmt.Fprintf(&b, "+%s\n", updatedLines[j])
+	}
+
+	return b.String()
+}
+
+// longestCommonSubsequence returns the longest sequence of lines common
+// to a and b, in order, via the standard O(len(a)*len(b)) DP.
+func longestCommonSubsequence(a, b []string) []string {
+	n, m := len(a), len(b)
+	length := make([][]int, n+1)
+	for i := range length {
+		length[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				length[i][j] = length[i+1][j+1] + 1
+			} else if length[i+1][j] >= length[i][j+1] {
+				length[i][j] = length[i+1][j]
+			} else {
+				length[i][j] = length[i][j+1]
+			}
+		}
+	}
+
+	var common []string
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case length[i+1][j] >= length[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return common
+}
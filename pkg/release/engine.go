@@ -0,0 +1,349 @@
+// Package release turns a set of SHIP components into an install plan,
+// applies it against a Kubernetes cluster - installing independent
+// components concurrently - while journaling progress, and can roll a
+// partially-applied plan back on failure.
+package release
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SprintHive/ship/pkg/helm"
+	"github.com/SprintHive/ship/pkg/kubectl"
+)
+
+// Phase identifies where in a component's install an Event was emitted.
+type Phase string
+
+const (
+	PhaseSkipped Phase = "skipped"
+	PhaseStart   Phase = "start"
+	PhaseDone    Phase = "done"
+	PhaseFailed  Phase = "failed"
+)
+
+// Event reports progress on a single component as Apply runs. Downstream
+// users embedding ship as a library can subscribe to Engine.Events
+// instead of scraping log output.
+type Event struct {
+	Component string
+	Phase     Phase
+	Err       error
+	Duration  time.Duration
+}
+
+// Engine plans and applies component installs.
+type Engine struct {
+	Domain            string
+	RollbackOnFailure bool
+	// Parallelism bounds how many independent components (per the
+	// DependsOn graph) may be installed at once. Values below 1 are
+	// treated as 1 (fully serial).
+	Parallelism int
+	// Events, if set, receives an Event for every component as it starts,
+	// finishes, fails, or is skipped during Apply. Sends block, so a slow
+	// or absent reader will stall Apply - give it enough buffer or drain
+	// it concurrently (see RenderProgress). A component that never starts
+	// because another component already failed gets no event.
+	Events chan<- Event
+}
+
+func (e *Engine) emit(component string, phase Phase, err error, since time.Time) {
+	if e.Events == nil {
+		return
+	}
+	e.Events <- Event{Component: component, Phase: phase, Err: err, Duration: time.Since(since)}
+}
+
+// NewEngine creates an Engine that installs charts against the given
+// ingress domain. RollbackOnFailure defaults to true and Parallelism
+// defaults to 1 (serial installation).
+func NewEngine(domain string) *Engine {
+	return &Engine{Domain: domain, RollbackOnFailure: true, Parallelism: 1}
+}
+
+// StepKind identifies what action a Step performs.
+type StepKind string
+
+const (
+	StepInstallChart   StepKind = "install-chart"
+	StepApplyManifest  StepKind = "apply-manifest"
+	StepDeleteManifest StepKind = "delete-manifest"
+	StepWaitReady      StepKind = "wait-ready"
+	StepWaitCompleted  StepKind = "wait-completed"
+)
+
+// Step is a single, independently undoable unit of work belonging to one
+// component.
+type Step struct {
+	Kind      StepKind
+	Component string
+	Chart     helm.Chart
+	Manifest  ManifestStep
+}
+
+// Plan produces a structured, ordered list of steps for the given
+// components. It has no side effects - nothing is touched on the cluster.
+// Apply and DryRun do not take this list: they take the components
+// themselves, and Apply derives the same steps per component as it goes.
+// Steps for independent
+// components may be applied concurrently by Apply; this flat list
+// preserves per-component ordering only.
+func (e *Engine) Plan(components []Component) []Step {
+	var steps []Step
+
+	for _, component := range components {
+		steps = append(steps, componentSteps(component)...)
+	}
+
+	return steps
+}
+
+func componentSteps(component Component) []Step {
+	var steps []Step
+	releaseName := component.Chart.ReleaseName
+
+	for _, manifest := range component.PreInstallResources {
+		steps = append(steps, manifestSteps(releaseName, manifest)...)
+	}
+
+	steps = append(steps, Step{Kind: StepInstallChart, Component: releaseName, Chart: component.Chart})
+
+	for _, manifest := range component.PostInstallResources {
+		steps = append(steps, manifestSteps(releaseName, manifest)...)
+	}
+
+	return steps
+}
+
+func manifestSteps(component string, manifest ManifestStep) []Step {
+	var steps []Step
+
+	if manifest.PreconditionReady.Resource != (KubernetesResource{}) {
+		steps = append(steps, Step{Kind: StepWaitReady, Component: component, Manifest: manifest})
+	}
+
+	steps = append(steps, Step{Kind: StepApplyManifest, Component: component, Manifest: manifest})
+
+	if manifest.WaitForDone != (KubernetesResource{}) {
+		steps = append(steps, Step{Kind: StepWaitCompleted, Component: component, Manifest: manifest})
+	}
+
+	if !manifest.PersistentAfterWait {
+		steps = append(steps, Step{Kind: StepDeleteManifest, Component: component, Manifest: manifest})
+	}
+
+	return steps
+}
+
+// Apply installs components, running components that don't depend on one
+// another concurrently (up to Parallelism at a time) and gating each
+// component's install on its own DependsOn list finishing successfully
+// first - a readiness wait inside one component only ever blocks that
+// component's dependents, never the whole install. Components that
+// already have a release installed are skipped entirely.
+//
+// Every completed step is journaled; if any component fails and
+// RollbackOnFailure is set, the journal accumulated so far across all
+// components is rolled back before the error is returned.
+func (e *Engine) Apply(components []Component) error {
+	dependents, err := buildDependencyGraph(components)
+	if err != nil {
+		return err
+	}
+
+	installed, err := helm.GetHelmReleases()
+	if err != nil {
+		return fmt.Errorf("failed to list existing helm releases: %w", err)
+	}
+	skip := make(map[string]struct{}, len(installed))
+	for _, release := range installed {
+		skip[release.Name] = struct{}{}
+	}
+
+	remaining := make(map[string]int, len(components))
+	byName := make(map[string]Component, len(components))
+	for _, component := range components {
+		remaining[component.Chart.ReleaseName] = len(component.DependsOn)
+		byName[component.Chart.ReleaseName] = component
+	}
+
+	parallelism := e.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		journal  []Step
+		firstErr error
+	)
+	sem := make(chan struct{}, parallelism)
+	done := make(chan string, len(components))
+
+	schedule := func(name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			mu.Lock()
+			abort := firstErr != nil
+			mu.Unlock()
+
+			if abort {
+				done <- name
+				return
+			}
+
+			if _, alreadyInstalled := skip[name]; alreadyInstalled {
+				e.emit(name, PhaseSkipped, nil, time.Now())
+				done <- name
+				return
+			}
+
+			start := time.Now()
+			e.emit(name, PhaseStart, nil, start)
+
+			for _, step := range componentSteps(byName[name]) {
+				if err := e.applyStep(step); err != nil {
+					wrapped := fmt.Errorf("component %q: %w", name, err)
+
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = wrapped
+					}
+					mu.Unlock()
+
+					e.emit(name, PhaseFailed, wrapped, start)
+					done <- name
+					return
+				}
+
+				mu.Lock()
+				journal = append(journal, step)
+				mu.Unlock()
+			}
+
+			e.emit(name, PhaseDone, nil, start)
+			done <- name
+		}()
+	}
+
+	for name, count := range remaining {
+		if count == 0 {
+			schedule(name)
+		}
+	}
+
+	for completed := 0; completed < len(components); completed++ {
+		name := <-done
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				schedule(dependent)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		if e.RollbackOnFailure {
+			if rbErr := e.Rollback(journal); rbErr != nil {
+				return fmt.Errorf("%v (rollback also failed: %v)", firstErr, rbErr)
+			}
+		}
+		return firstErr
+	}
+
+	return nil
+}
+
+func (e *Engine) applyStep(step Step) error {
+	switch step.Kind {
+	case StepInstallChart:
+		chart := step.Chart
+		return helm.InstallChart(&chart, e.Domain)
+	case StepApplyManifest:
+		// TODO: Fix hardcoded infra namespace
+		return kubectl.Create(step.Manifest.ManifestPath, "infra")
+	case StepDeleteManifest:
+		// TODO: Fix hardcoded infra namespace
+		return kubectl.Delete(step.Manifest.ManifestPath, "infra")
+	case StepWaitReady:
+		resource := step.Manifest.PreconditionReady.Resource
+		return waitForResourceReady(&resource, step.Manifest.PreconditionReady.MinReplicas)
+	case StepWaitCompleted:
+		resource := step.Manifest.WaitForDone
+		return waitForResourceCompleted(&resource)
+	default:
+		return fmt.Errorf("unknown step kind: %s", step.Kind)
+	}
+}
+
+// Rollback undoes a journal of already-applied steps, most recent first:
+// charts that were installed are uninstalled, and manifests that were
+// created (and not already cleaned up) are deleted.
+func (e *Engine) Rollback(journal []Step) error {
+	for i := len(journal) - 1; i >= 0; i-- {
+		step := journal[i]
+
+		switch step.Kind {
+		case StepInstallChart:
+			if err := helm.UninstallRelease(step.Chart.ReleaseName, step.Chart.Namespace); err != nil {
+				return fmt.Errorf("failed to roll back chart %q: %w", step.Chart.ReleaseName, err)
+			}
+		case StepApplyManifest:
+			// TODO: Fix hardcoded infra namespace
+			kubectl.Delete(step.Manifest.ManifestPath, "infra")
+		}
+	}
+
+	return nil
+}
+
+// DryRun renders each component's chart via Helm's client-only install
+// path, without touching the cluster. For a component with no installed
+// release, the full rendered manifest is printed; for one that's already
+// installed, the render is diffed against the installed release's own
+// manifest instead.
+func (e *Engine) DryRun(components []Component) error {
+	installed, err := helm.GetHelmReleases()
+	if err != nil {
+		return fmt.Errorf("failed to list existing helm releases: %w", err)
+	}
+
+	installedByName := make(map[string]helm.Release, len(installed))
+	for _, release := range installed {
+		installedByName[release.Name] = release
+	}
+
+	for _, component := range components {
+		releaseName := component.Chart.ReleaseName
+
+		manifest, err := helm.RenderChart(&component.Chart, e.Domain)
+		if err != nil {
+			return fmt.Errorf("failed to render chart %q: %w", component.Chart.ChartPath, err)
+		}
+
+		installedRelease, found := installedByName[releaseName]
+		if !found {
+			fmt.Printf("--- %s (new release) ---\n%s\n", releaseName, manifest)
+			continue
+		}
+
+		current, err := helm.GetRelease(installedRelease.Name, installedRelease.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get installed release %q: %w", releaseName, err)
+		}
+
+		fmt.Printf("--- %s (diff against installed revision %d) ---\n%s\n", releaseName, current.Version, renderDiff(current.Manifest, manifest))
+	}
+
+	return nil
+}
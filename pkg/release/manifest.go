@@ -0,0 +1,19 @@
+package release
+
+// ManagedManifests returns the pre/post-install manifests of component
+// that are left on the cluster after install (PersistentAfterWait) - i.e.
+// the ones that are still there, labelled, for status to find later.
+func ManagedManifests(component Component) []ManifestStep {
+	var manifests []ManifestStep
+	for _, manifest := range component.PreInstallResources {
+		if manifest.PersistentAfterWait {
+			manifests = append(manifests, manifest)
+		}
+	}
+	for _, manifest := range component.PostInstallResources {
+		if manifest.PersistentAfterWait {
+			manifests = append(manifests, manifest)
+		}
+	}
+	return manifests
+}
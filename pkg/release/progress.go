@@ -0,0 +1,28 @@
+package release
+
+import (
+	"fmt"
+	"time"
+)
+
+// RenderProgress consumes events until the channel is closed, printing a
+// status line when a component starts and another once it finishes, fails,
+// or is found already installed. Lines are appended as events arrive and
+// are never updated in place, so a component that starts gets two lines,
+// interleaved with those of components running alongside it. It's meant
+// for interactive use; library users who want structured data should read
+// Engine.Events themselves instead.
+func RenderProgress(events <-chan Event) {
+	for event := range events {
+		switch event.Phase {
+		case PhaseSkipped:
+			fmt.Printf("- %s: already installed, skipping\n", event.Component)
+		case PhaseStart:
+			fmt.Printf("* %s: installing...\n", event.Component)
+		case PhaseDone:
+			fmt.Printf("✓ %s: installed in %s\n", event.Component, event.Duration.Round(time.Millisecond))
+		case PhaseFailed:
+			fmt.Printf("x %s: failed after %s: %v\n", event.Component, event.Duration.Round(time.Millisecond), event.Err)
+		}
+	}
+}
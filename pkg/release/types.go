@@ -0,0 +1,44 @@
+package release
+
+import "github.com/SprintHive/ship/pkg/helm"
+
+// KubernetesResource identifies a single Kubernetes resource (by type,
+// name, and namespace) that a component's manifests can wait on.
+type KubernetesResource struct {
+	Type      string
+	Name      string
+	Namespace string
+}
+
+// ResourcePrecondition describes a resource that must already be ready
+// (e.g. have a minimum number of replicas) before a manifest is applied.
+type ResourcePrecondition struct {
+	Resource    KubernetesResource
+	MinReplicas int
+}
+
+// ManifestStep describes a single pre- or post-install manifest applied
+// around a component's chart installation.
+type ManifestStep struct {
+	ManifestPath        string
+	PreconditionReady   ResourcePrecondition
+	WaitForDone         KubernetesResource
+	PersistentAfterWait bool
+}
+
+// Component is a single unit ship installs: a helm chart, plus any
+// manifests that must be applied immediately before or after it, and the
+// release names of any other components that must finish installing
+// first.
+type Component struct {
+	Chart                helm.Chart
+	PreInstallResources  []ManifestStep
+	PostInstallResources []ManifestStep
+	DependsOn            []string
+}
+
+// HelmChart identifies a previously-installed release by name, used by
+// `ship destroy` to tear down a SHIP installation.
+type HelmChart struct {
+	ReleaseName string
+}
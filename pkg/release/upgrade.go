@@ -0,0 +1,66 @@
+package release
+
+import (
+	"fmt"
+
+	"github.com/SprintHive/ship/pkg/helm"
+)
+
+// Upgrade upgrades each already-installed component's release to its
+// currently configured chart, in dependency order. Components with no
+// installed release are skipped - Upgrade never installs. versionOverrides
+// lets a caller pin a specific chart version per release name (e.g. from a
+// --version flag), overriding whatever ChartVersion is set in config.
+func (e *Engine) Upgrade(components []Component, versionOverrides map[string]string, reuseValues, force bool) error {
+	installedReleases, err := helm.GetHelmReleases()
+	if err != nil {
+		return fmt.Errorf("failed to list existing helm releases: %w", err)
+	}
+	installed := make(map[string]struct{}, len(installedReleases))
+	for _, release := range installedReleases {
+		installed[release.Name] = struct{}{}
+	}
+
+	var resolved []Component
+	for _, component := range components {
+		if _, found := installed[component.Chart.ReleaseName]; !found {
+			continue
+		}
+		if version, found := versionOverrides[component.Chart.ReleaseName]; found {
+			component.Chart.ChartVersion = version
+		}
+		component.DependsOn = pruneDependsOn(component.DependsOn, installed)
+		resolved = append(resolved, component)
+	}
+
+	dependents, err := buildDependencyGraph(resolved)
+	if err != nil {
+		return err
+	}
+
+	order, err := topoOrder(resolved, dependents)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range order {
+		if err := helm.UpgradeChart(&component.Chart, e.Domain, reuseValues, force); err != nil {
+			return fmt.Errorf("component %q: %w", component.Chart.ReleaseName, err)
+		}
+	}
+
+	return nil
+}
+
+// pruneDependsOn drops any dependency not in installed, so a component
+// whose not-yet-installed dependency was filtered out of an upgrade still
+// builds a valid dependency graph.
+func pruneDependsOn(dependsOn []string, installed map[string]struct{}) []string {
+	var pruned []string
+	for _, dep := range dependsOn {
+		if _, found := installed[dep]; found {
+			pruned = append(pruned, dep)
+		}
+	}
+	return pruned
+}
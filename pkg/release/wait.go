@@ -0,0 +1,33 @@
+package release
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/SprintHive/ship/pkg/kubectl"
+)
+
+func waitForResourceReady(kubeResource *KubernetesResource, minReplicas int) error {
+	if kubeResource.Type == "deployment" {
+		kubectl.WaitDeployReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
+	} else if kubeResource.Type == "daemonset" {
+		kubectl.WaitDaemonSetReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
+	} else if kubeResource.Type == "statefulset" {
+		kubectl.WaitStatefulSetReady(kubeResource.Name, minReplicas, kubeResource.Namespace)
+	} else {
+		return fmt.Errorf("unsupported wait precondition type: %s", kubeResource.Type)
+	}
+
+	return nil
+}
+
+func waitForResourceCompleted(kubeResource *KubernetesResource) error {
+	if kubeResource.Type == "pod" {
+		kubectl.WaitPodCompleted(kubeResource.Name, kubeResource.Namespace)
+	} else {
+		fmt.Fprintf(os.Stderr, fmt.Sprintf("Unsupported wait type: %s\n", kubeResource.Type))
+		return fmt.Errorf("unsupported wait resource type: %s", kubeResource.Type)
+	}
+
+	return nil
+}